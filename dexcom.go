@@ -2,29 +2,37 @@ package dexgo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"regexp"
-	"strconv"
 	"time"
 )
 
-const dexcomBaseUrl = "https://share2.dexcom.com/ShareWebServices/Services"
+// defaultBaseURL is the US Dexcom Share host, used unless overridden via
+// NewWithRegion or WithBaseURL.
+const defaultBaseURL = "https://share2.dexcom.com/ShareWebServices/Services"
 
 const dexcomLoginEndpoint = "General/LoginPublisherAccountById"
 const dexcomAuthEndpoint = "General/AuthenticatePublisherAccount"
 const dexcomGetLatestEndpoint = "Publisher/ReadPublisherLatestGlucoseValues"
-const dexcomApplicationId = "d89443d2-327c-4a6f-89e5-496bbb0317db"
 
-var timestampRegex = regexp.MustCompile(`Date\((\d*)\)`)
+// defaultApplicationId is the US/OUS Share application id, used unless
+// overridden via NewWithRegion or WithApplicationId.
+const defaultApplicationId = "d89443d2-327c-4a6f-89e5-496bbb0317db"
 
-type GlucoseReading struct {
-	Time  time.Time
-	Value int
-	Trend string
+// retry tuning for transient network/5xx failures. Bounded by the caller's
+// context deadline, not by wall-clock time.
+const maxRetries = 3
+const initialBackoff = 250 * time.Millisecond
+
+// sessionErrorCodes are the Share error envelope codes that mean our cached
+// session has expired and a fresh accountId+sessionId pair is needed.
+var sessionErrorCodes = map[string]bool{
+	"SessionIdNotFound": true,
+	"SessionNotValid":   true,
 }
 
 type VerifyPayload struct {
@@ -45,46 +53,194 @@ type GetReadingsPayload struct {
 	MaxCount  int    `json:"maxCount"`
 }
 
-type RawReading struct {
-	Time  string `json:"WT"`
-	Trend string `json:"Trend"`
-	Value int    `json:"Value"`
+// dexcomError is the envelope the Share API returns in place of the expected
+// payload when something went wrong (bad credentials, expired session, ...).
+type dexcomError struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
+}
+
+func (e *dexcomError) Error() string {
+	return fmt.Sprintf("dexcom: %s: %s", e.Code, e.Message)
+}
+
+func (e *dexcomError) isSessionExpired() bool {
+	return e != nil && sessionErrorCodes[e.Code]
 }
 
 type Dexcom struct {
-	username  string
-	password  string
-	accountId *string
-	sessionId *string
+	username           string
+	password           string
+	accountId          *string
+	sessionId          *string
+	httpClient         *http.Client
+	baseURL            string
+	applicationId      string
+	sessionStore       SessionStore
+	sessionRefreshHook func()
 }
 
-func New(username string, password string) Dexcom {
-	return Dexcom{username: username, password: password}
+// Option configures a Dexcom client created via New or NewWithRegion.
+type Option func(*Dexcom)
+
+// WithHTTPClient overrides the http.Client used for all requests, letting
+// callers set timeouts, TLS config, or proxies.
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *Dexcom) {
+		d.httpClient = client
+	}
 }
 
-func request(endPoint string, payload any, result interface{}) error {
-	payloadJSON, err := json.Marshal(payload)
-	if err != nil {
-		return err
+// WithBaseURL overrides the Dexcom Share host entirely, e.g. to point at a
+// self-hosted proxy. Takes precedence over any region passed to
+// NewWithRegion.
+func WithBaseURL(url string) Option {
+	return func(d *Dexcom) {
+		d.baseURL = url
+	}
+}
+
+// WithApplicationId overrides the Share applicationId sent with auth
+// requests. Takes precedence over any region passed to NewWithRegion.
+func WithApplicationId(applicationId string) Option {
+	return func(d *Dexcom) {
+		d.applicationId = applicationId
+	}
+}
+
+// WithSessionStore persists the accountId/sessionId pair across process
+// restarts, so short-lived CLIs and cron jobs don't re-authenticate with
+// Dexcom on every run.
+func WithSessionStore(store SessionStore) Option {
+	return func(d *Dexcom) {
+		d.sessionStore = store
+	}
+}
+
+func New(username string, password string, opts ...Option) Dexcom {
+	d := Dexcom{username: username, password: password}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return d
+}
+
+func (d *Dexcom) client() *http.Client {
+	if d.httpClient != nil {
+		return d.httpClient
+	}
+	return http.DefaultClient
+}
+
+func (d *Dexcom) baseOrDefault() string {
+	if d.baseURL != "" {
+		return d.baseURL
 	}
-	authUrl, _ := url.JoinPath(dexcomBaseUrl, endPoint)
-	resp, err := http.Post(authUrl, "application/json", bytes.NewBuffer(payloadJSON))
+	return defaultBaseURL
+}
+
+func (d *Dexcom) applicationIdOrDefault() string {
+	if d.applicationId != "" {
+		return d.applicationId
+	}
+	return defaultApplicationId
+}
+
+// request posts payload to endPoint and decodes the response into result,
+// retrying transient network/5xx failures with exponential backoff up to
+// maxRetries times, stopping early if ctx is done.
+func request(ctx context.Context, client *http.Client, baseURL string, endPoint string, payload any, result interface{}) error {
+	body, err := requestRaw(ctx, client, baseURL, endPoint, payload)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	return decodeOrError(body, result)
+}
+
+func requestRaw(ctx context.Context, client *http.Client, baseURL string, endPoint string, payload any) ([]byte, error) {
+	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
+		return nil, err
+	}
+	authUrl, _ := url.JoinPath(baseURL, endPoint)
+
+	backoff := initialBackoff
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, authUrl, bytes.NewReader(payloadJSON))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			// The Share API returns its error envelope (including the
+			// SessionIdNotFound/SessionNotValid expiry codes) with a 5xx
+			// status instead of 200, so a parseable envelope here is a
+			// permanent, not transient, failure: return it immediately and
+			// let decodeOrError surface it instead of burning retries.
+			if parseDexcomError(body) != nil {
+				return body, nil
+			}
+			lastErr = fmt.Errorf("dexcom: server error %d: %s", resp.StatusCode, body)
+			continue
+		}
+		return body, nil
+	}
+	return nil, lastErr
+}
+
+// decodeOrError unmarshals body into result, or into a dexcomError if the
+// Share API returned its error envelope instead of the expected shape.
+func decodeOrError(body []byte, result interface{}) error {
+	if err := json.Unmarshal(body, result); err != nil {
+		if dexErr := parseDexcomError(body); dexErr != nil {
+			return dexErr
+		}
 		return err
 	}
-	err = json.Unmarshal(body, result)
-	return err
+	return nil
+}
+
+// parseDexcomError reports whether body is the Share API's error envelope,
+// returning it if so. The Share API uses this envelope on both 200 and 5xx
+// responses.
+func parseDexcomError(body []byte) *dexcomError {
+	var dexErr dexcomError
+	if err := json.Unmarshal(body, &dexErr); err == nil && dexErr.Code != "" {
+		return &dexErr
+	}
+	return nil
 }
 
 func (d *Dexcom) fetchAccountId() error {
-	payload := VerifyPayload{AccountName: d.username, Password: d.password, ApplicationId: dexcomApplicationId}
+	return d.fetchAccountIdContext(context.Background())
+}
+
+func (d *Dexcom) fetchAccountIdContext(ctx context.Context) error {
+	payload := VerifyPayload{AccountName: d.username, Password: d.password, ApplicationId: d.applicationIdOrDefault()}
 	var accountId string
-	err := request(dexcomAuthEndpoint, payload, &accountId)
+	err := request(ctx, d.client(), d.baseOrDefault(), dexcomAuthEndpoint, payload, &accountId)
 	if err != nil {
 		return err
 	}
@@ -93,9 +249,13 @@ func (d *Dexcom) fetchAccountId() error {
 }
 
 func (d *Dexcom) auth() error {
-	payload := AuthPayload{AccountId: *d.accountId, Password: d.password, ApplicationId: dexcomApplicationId}
+	return d.authContext(context.Background())
+}
+
+func (d *Dexcom) authContext(ctx context.Context) error {
+	payload := AuthPayload{AccountId: *d.accountId, Password: d.password, ApplicationId: d.applicationIdOrDefault()}
 	var sessionId string
-	err := request(dexcomLoginEndpoint, payload, &sessionId)
+	err := request(ctx, d.client(), d.baseOrDefault(), dexcomLoginEndpoint, payload, &sessionId)
 	if err != nil {
 		return err
 	}
@@ -103,47 +263,98 @@ func (d *Dexcom) auth() error {
 	return nil
 }
 
-func convertTimestamp(wt string) (time.Time, error) {
-	matches := timestampRegex.FindStringSubmatch(wt)
-	if len(matches) != 2 {
-		return time.Time{}, fmt.Errorf("failed to parse timestamp: %s", wt)
+// ensureSession makes sure we hold an accountId and sessionId, preferring a
+// cached session from the SessionStore (if any) and otherwise fetching both
+// fresh.
+func (d *Dexcom) ensureSession(ctx context.Context) error {
+	if d.sessionId != nil {
+		return nil
 	}
-	timeMillis, err := strconv.Atoi(matches[1])
-	if err != nil {
-		return time.Time{}, fmt.Errorf("invalid timestamp: %s", matches[1])
+	if d.accountId == nil && d.sessionStore != nil {
+		if accountId, sessionId, err := d.sessionStore.Load(d.username); err == nil && sessionId != "" {
+			d.accountId = &accountId
+			d.sessionId = &sessionId
+			return nil
+		}
 	}
-	return time.UnixMilli(int64(timeMillis)), nil
+	if d.accountId == nil {
+		if err := d.fetchAccountIdContext(ctx); err != nil {
+			return err
+		}
+	}
+	if err := d.authContext(ctx); err != nil {
+		return err
+	}
+	d.saveSession()
+	return nil
 }
 
-func (d *Dexcom) GetReadings(minutes int, numReadings int) ([]GlucoseReading, error) {
-	if d.sessionId == nil {
-		if d.accountId == nil {
-			d.fetchAccountId()
-		}
-		d.auth()
+func (d *Dexcom) saveSession() {
+	if d.sessionStore == nil || d.accountId == nil || d.sessionId == nil {
+		return
 	}
-	payload := GetReadingsPayload{
-		SessionId: *d.sessionId,
-		Minutes:   minutes,
-		MaxCount:  numReadings,
+	_ = d.sessionStore.Save(d.username, *d.accountId, *d.sessionId)
+}
+
+// reauth drops the cached session, both in memory and in the SessionStore
+// if one is configured, and fetches a brand new accountId+sessionId pair.
+// Used after the Share API tells us our session has expired.
+func (d *Dexcom) reauth(ctx context.Context) error {
+	d.accountId = nil
+	d.sessionId = nil
+	if d.sessionStore != nil {
+		_ = d.sessionStore.Clear(d.username)
 	}
-	var rawValues []RawReading
-	err := request(dexcomGetLatestEndpoint, payload, &rawValues)
-	if err != nil {
+	if err := d.ensureSession(ctx); err != nil {
+		return err
+	}
+	if d.sessionRefreshHook != nil {
+		d.sessionRefreshHook()
+	}
+	return nil
+}
+
+// OnSessionRefresh registers fn to be called each time a cached session is
+// found to have expired and is successfully replaced via reauth. Intended
+// for callers that want to observe refreshes directly (e.g. the prom
+// subpackage's refresh counter) rather than by inspecting errors, since a
+// successful reauth-and-retry never surfaces an error at all.
+func (d *Dexcom) OnSessionRefresh(fn func()) {
+	d.sessionRefreshHook = fn
+}
+
+func (d *Dexcom) GetReadings(minutes int, numReadings int) ([]GlucoseReading, error) {
+	return d.GetReadingsContext(context.Background(), minutes, numReadings)
+}
+
+// GetReadingsContext fetches the latest numReadings glucose readings from
+// the last `minutes` minutes. It honors ctx for cancellation/timeouts and,
+// if the cached session has expired, transparently re-authenticates once
+// and retries before giving up.
+func (d *Dexcom) GetReadingsContext(ctx context.Context, minutes int, numReadings int) ([]GlucoseReading, error) {
+	if err := d.ensureSession(ctx); err != nil {
 		return nil, err
 	}
+
 	var readings []GlucoseReading
-	for _, rv := range rawValues {
-		timestamp, err := convertTimestamp(rv.Time)
-		if err != nil {
+	err := d.getLatest(ctx, minutes, numReadings, &readings)
+	if dexErr, ok := err.(*dexcomError); ok && dexErr.isSessionExpired() {
+		if err := d.reauth(ctx); err != nil {
 			return nil, err
 		}
-		r := GlucoseReading{
-			Trend: rv.Trend,
-			Value: rv.Value,
-			Time:  timestamp,
-		}
-		readings = append(readings, r)
+		err = d.getLatest(ctx, minutes, numReadings, &readings)
+	}
+	if err != nil {
+		return nil, err
 	}
 	return readings, nil
 }
+
+func (d *Dexcom) getLatest(ctx context.Context, minutes int, numReadings int, result interface{}) error {
+	payload := GetReadingsPayload{
+		SessionId: *d.sessionId,
+		Minutes:   minutes,
+		MaxCount:  numReadings,
+	}
+	return request(ctx, d.client(), d.baseOrDefault(), dexcomGetLatestEndpoint, payload, result)
+}
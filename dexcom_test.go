@@ -0,0 +1,127 @@
+package dexgo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// TestGetReadingsContextRecoversFromExpiredSession verifies that a
+// SessionIdNotFound/SessionNotValid envelope returned with a 5xx status
+// (as the real Share API does) is detected as a session expiry rather than
+// retried as a generic transient server error, and that GetReadingsContext
+// transparently re-authenticates and retries once.
+func TestGetReadingsContextRecoversFromExpiredSession(t *testing.T) {
+	var accountCalls, loginCalls, readingsCalls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/"+dexcomAuthEndpoint:
+			atomic.AddInt32(&accountCalls, 1)
+			fmt.Fprint(w, `"account-1"`)
+		case r.URL.Path == "/"+dexcomLoginEndpoint:
+			atomic.AddInt32(&loginCalls, 1)
+			fmt.Fprint(w, `"session-1"`)
+		case r.URL.Path == "/"+dexcomGetLatestEndpoint:
+			n := atomic.AddInt32(&readingsCalls, 1)
+			if n == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"Code":"SessionIdNotFound","Message":"Session not found"}`)
+				return
+			}
+			fmt.Fprint(w, `[{"WT":"/Date(1700000000000)/","Value":100,"Trend":4}]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	d := New("alice", "hunter2", WithHTTPClient(server.Client()), WithBaseURL(server.URL))
+
+	readings, err := d.GetReadingsContext(context.Background(), 30, 1)
+	if err != nil {
+		t.Fatalf("GetReadingsContext: %v", err)
+	}
+	if len(readings) != 1 || readings[0].Value != 100 {
+		t.Fatalf("readings = %+v, want a single reading with value 100", readings)
+	}
+
+	if got := atomic.LoadInt32(&readingsCalls); got != 2 {
+		t.Errorf("readings endpoint called %d times, want 2 (no extra 5xx retries)", got)
+	}
+	if got := atomic.LoadInt32(&accountCalls); got != 2 {
+		t.Errorf("account endpoint called %d times, want 2 (initial auth + reauth)", got)
+	}
+	if got := atomic.LoadInt32(&loginCalls); got != 2 {
+		t.Errorf("login endpoint called %d times, want 2 (initial auth + reauth)", got)
+	}
+}
+
+func TestParseDexcomErrorIgnoresNonEnvelopeBody(t *testing.T) {
+	if dexErr := parseDexcomError([]byte(`[1,2,3]`)); dexErr != nil {
+		t.Errorf("parseDexcomError(non-envelope) = %v, want nil", dexErr)
+	}
+	if dexErr := parseDexcomError([]byte(`{"Code":"SessionIdNotFound","Message":"x"}`)); dexErr == nil {
+		t.Error("parseDexcomError(envelope) = nil, want *dexcomError")
+	}
+}
+
+// TestGetReadingsContextSelfHealsStaleCachedSession simulates a process
+// restart that loads a stale session out of a FileSessionStore: the first
+// request against it must be detected as expired (per the 5xx-envelope fix
+// above), which should clear the store and persist the freshly
+// re-authenticated session, letting the process recover on its own.
+func TestGetReadingsContextSelfHealsStaleCachedSession(t *testing.T) {
+	var accountCalls, readingsCalls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/"+dexcomAuthEndpoint:
+			atomic.AddInt32(&accountCalls, 1)
+			fmt.Fprint(w, `"account-fresh"`)
+		case r.URL.Path == "/"+dexcomLoginEndpoint:
+			fmt.Fprint(w, `"session-fresh"`)
+		case r.URL.Path == "/"+dexcomGetLatestEndpoint:
+			if atomic.AddInt32(&readingsCalls, 1) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"Code":"SessionNotValid","Message":"Session not valid"}`)
+				return
+			}
+			fmt.Fprint(w, `[{"WT":"/Date(1700000000000)/","Value":90,"Trend":4}]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store := &FileSessionStore{path: filepath.Join(t.TempDir(), "sessions.json"), password: "hunter2"}
+	if err := store.Save("alice", "account-stale", "session-stale"); err != nil {
+		t.Fatalf("seeding stale session: %v", err)
+	}
+
+	d := New("alice", "hunter2", WithHTTPClient(server.Client()), WithBaseURL(server.URL), WithSessionStore(store))
+
+	readings, err := d.GetReadingsContext(context.Background(), 30, 1)
+	if err != nil {
+		t.Fatalf("GetReadingsContext: %v", err)
+	}
+	if len(readings) != 1 || readings[0].Value != 90 {
+		t.Fatalf("readings = %+v, want a single reading with value 90", readings)
+	}
+
+	accountId, sessionId, err := store.Load("alice")
+	if err != nil {
+		t.Fatalf("Load after self-heal: %v", err)
+	}
+	if accountId != "account-fresh" || sessionId != "session-fresh" {
+		t.Errorf("store holds (%q, %q) after self-heal, want (account-fresh, session-fresh): stale session was never replaced", accountId, sessionId)
+	}
+}
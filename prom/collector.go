@@ -0,0 +1,112 @@
+// Package prom exposes a Dexcom client's glucose readings as Prometheus
+// metrics, suitable for scraping by node_exporter/Grafana setups.
+package prom
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dierksen/dexgo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Collector implements prometheus.Collector over a *dexgo.Dexcom. It never
+// calls Dexcom on a scrape; Start subscribes to new readings in the
+// background and Collect just reports whatever's cached, so scrapes are
+// cheap and don't hammer the Share API.
+type Collector struct {
+	dex *dexgo.Dexcom
+
+	mu         sync.RWMutex
+	latest     dexgo.GlucoseReading
+	hasReading bool
+
+	glucoseDesc *prometheus.Desc
+	trendDesc   *prometheus.Desc
+	ageDesc     *prometheus.Desc
+
+	apiErrors     prometheus.Counter
+	authRefreshes prometheus.Counter
+}
+
+// NewCollector wraps dex for Prometheus export. Call Start before
+// registering it so the cache has something to serve.
+func NewCollector(dex *dexgo.Dexcom) *Collector {
+	c := &Collector{
+		dex: dex,
+		glucoseDesc: prometheus.NewDesc(
+			"dexcom_glucose_mgdl", "Latest glucose reading, in mg/dL.", nil, nil),
+		trendDesc: prometheus.NewDesc(
+			"dexcom_glucose_trend", "Latest glucose trend, numeric 1-9.", nil, nil),
+		ageDesc: prometheus.NewDesc(
+			"dexcom_reading_age_seconds", "Age of the latest cached reading, in seconds.", nil, nil),
+		apiErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dexcom_api_errors_total", Help: "Errors returned by the Share API while polling."}),
+		authRefreshes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dexcom_auth_refreshes_total", Help: "Times the cached session expired and was refreshed."}),
+	}
+	dex.OnSessionRefresh(c.authRefreshes.Inc)
+	return c
+}
+
+// Start subscribes to new readings in the background, keeping the cache
+// fresh until ctx is done. It must be called once before the collector is
+// scraped.
+func (c *Collector) Start(ctx context.Context) {
+	readings, errs := c.dex.Subscribe(ctx, dexgo.SubscribeOptions{Backfill: true})
+	go func() {
+		for {
+			select {
+			case r, ok := <-readings:
+				if !ok {
+					return
+				}
+				c.mu.Lock()
+				c.latest = r
+				c.hasReading = true
+				c.mu.Unlock()
+			case _, ok := <-errs:
+				if !ok {
+					continue
+				}
+				c.apiErrors.Inc()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.glucoseDesc
+	ch <- c.trendDesc
+	ch <- c.ageDesc
+	c.apiErrors.Describe(ch)
+	c.authRefreshes.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	reading, ok := c.latest, c.hasReading
+	c.mu.RUnlock()
+
+	if ok {
+		ch <- prometheus.MustNewConstMetric(c.glucoseDesc, prometheus.GaugeValue, float64(reading.Value))
+		ch <- prometheus.MustNewConstMetric(c.trendDesc, prometheus.GaugeValue, float64(reading.Trend))
+		ch <- prometheus.MustNewConstMetric(c.ageDesc, prometheus.GaugeValue, time.Since(reading.Time).Seconds())
+	}
+	c.apiErrors.Collect(ch)
+	c.authRefreshes.Collect(ch)
+}
+
+// Handler returns an http.Handler serving c's metrics from its own
+// registry on whatever path it is mounted at, ready to be mounted at
+// /metrics on an http.ServeMux.
+func (c *Collector) Handler() http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
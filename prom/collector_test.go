@@ -0,0 +1,58 @@
+package prom
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dierksen/dexgo"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestCollectorCountsSessionRefreshesViaHook verifies that
+// dexcom_auth_refreshes_total is incremented from dexgo's OnSessionRefresh
+// hook at the moment a refresh happens, rather than by pattern-matching
+// errors on the Subscribe error channel (which a successful reauth never
+// reaches).
+func TestCollectorCountsSessionRefreshesViaHook(t *testing.T) {
+	var readingsCalls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/General/AuthenticatePublisherAccount":
+			fmt.Fprint(w, `"account-1"`)
+		case "/General/LoginPublisherAccountById":
+			fmt.Fprint(w, `"session-1"`)
+		case "/Publisher/ReadPublisherLatestGlucoseValues":
+			if atomic.AddInt32(&readingsCalls, 1) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"Code":"SessionIdNotFound","Message":"Session not found"}`)
+				return
+			}
+			fmt.Fprint(w, `[{"WT":"/Date(1700000000000)/","Value":100,"Trend":4}]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dex := dexgo.New("alice", "hunter2", dexgo.WithHTTPClient(server.Client()), dexgo.WithBaseURL(server.URL))
+	c := NewCollector(&dex)
+
+	if testutil.ToFloat64(c.authRefreshes) != 0 {
+		t.Fatal("authRefreshes should start at 0")
+	}
+
+	if _, err := dex.GetReadingsContext(context.Background(), 30, 1); err != nil {
+		t.Fatalf("GetReadingsContext: %v", err)
+	}
+
+	if got := testutil.ToFloat64(c.authRefreshes); got != 1 {
+		t.Errorf("dexcom_auth_refreshes_total = %v, want 1", got)
+	}
+}
@@ -0,0 +1,181 @@
+package dexgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Trend is the direction and rate of change of a glucose reading. Dexcom
+// returns it as a number (1-9) from some endpoints and as a name from
+// others; Trend unmarshals both.
+type Trend int8
+
+const (
+	TrendNone           Trend = 0
+	TrendDoubleUp       Trend = 1
+	TrendSingleUp       Trend = 2
+	TrendFortyFiveUp    Trend = 3
+	TrendFlat           Trend = 4
+	TrendFortyFiveDown  Trend = 5
+	TrendSingleDown     Trend = 6
+	TrendDoubleDown     Trend = 7
+	TrendNotComputable  Trend = 8
+	TrendRateOutOfRange Trend = 9
+)
+
+var trendNames = map[Trend]string{
+	TrendNone:           "None",
+	TrendDoubleUp:       "DoubleUp",
+	TrendSingleUp:       "SingleUp",
+	TrendFortyFiveUp:    "FortyFiveUp",
+	TrendFlat:           "Flat",
+	TrendFortyFiveDown:  "FortyFiveDown",
+	TrendSingleDown:     "SingleDown",
+	TrendDoubleDown:     "DoubleDown",
+	TrendNotComputable:  "NotComputable",
+	TrendRateOutOfRange: "RateOutOfRange",
+}
+
+var trendArrows = map[Trend]string{
+	TrendNone:           "?",
+	TrendDoubleUp:       "↑↑",
+	TrendSingleUp:       "↑",
+	TrendFortyFiveUp:    "↗",
+	TrendFlat:           "→",
+	TrendFortyFiveDown:  "↘",
+	TrendSingleDown:     "↓",
+	TrendDoubleDown:     "↓↓",
+	TrendNotComputable:  "?",
+	TrendRateOutOfRange: "?",
+}
+
+var trendByName = func() map[string]Trend {
+	m := make(map[string]Trend, len(trendNames))
+	for t, name := range trendNames {
+		m[name] = t
+	}
+	return m
+}()
+
+// String returns the Dexcom wire name for the trend, e.g. "FortyFiveUp".
+func (t Trend) String() string {
+	if name, ok := trendNames[t]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// Arrow returns a short glyph for the trend, e.g. "↑" for SingleUp.
+func (t Trend) Arrow() string {
+	if arrow, ok := trendArrows[t]; ok {
+		return arrow
+	}
+	return "?"
+}
+
+func (t Trend) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON accepts both the numeric form (1-9) some endpoints return
+// and the named form (e.g. "FortyFiveUp") others do.
+func (t *Trend) UnmarshalJSON(data []byte) error {
+	var n int8
+	if err := json.Unmarshal(data, &n); err == nil {
+		*t = Trend(n)
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("dexgo: invalid trend %s: %w", data, err)
+	}
+	trend, ok := trendByName[name]
+	if !ok {
+		return fmt.Errorf("dexgo: unknown trend name %q", name)
+	}
+	*t = trend
+	return nil
+}
+
+type GlucoseReading struct {
+	Time  time.Time
+	Value int
+	Trend Trend
+}
+
+var timestampRegex = regexp.MustCompile(`Date\((\d*)\)`)
+
+func convertTimestamp(wt string) (time.Time, error) {
+	matches := timestampRegex.FindStringSubmatch(wt)
+	if len(matches) != 2 {
+		return time.Time{}, fmt.Errorf("failed to parse timestamp: %s", wt)
+	}
+	timeMillis, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp: %s", matches[1])
+	}
+	return time.UnixMilli(int64(timeMillis)), nil
+}
+
+// wireReading mirrors the raw shape the Share API returns: a Microsoft
+// `/Date(millis)/` string for the timestamp, and WT/ST/DT all present
+// depending on endpoint (we only need WT, which is always populated).
+type wireReading struct {
+	WT    string          `json:"WT"`
+	ST    string          `json:"ST,omitempty"`
+	DT    string          `json:"DT,omitempty"`
+	Value int             `json:"Value"`
+	Trend json.RawMessage `json:"Trend"`
+}
+
+// normalizedReading is the stable public JSON form of a GlucoseReading.
+type normalizedReading struct {
+	Time  time.Time `json:"time"`
+	Value int       `json:"value"`
+	Trend Trend     `json:"trend"`
+}
+
+// MarshalJSON always emits the normalized public form, regardless of which
+// form this reading was unmarshalled from.
+func (r GlucoseReading) MarshalJSON() ([]byte, error) {
+	return json.Marshal(normalizedReading{Time: r.Time, Value: r.Value, Trend: r.Trend})
+}
+
+// UnmarshalJSON accepts either the raw Dexcom wire format (WT/Value/Trend)
+// or the normalized public form (time/value/trend), so a GlucoseReading
+// round-trips cleanly through either.
+func (r *GlucoseReading) UnmarshalJSON(data []byte) error {
+	if bytes.Contains(data, []byte(`"WT"`)) {
+		var raw wireReading
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		timestamp, err := convertTimestamp(raw.WT)
+		if err != nil {
+			return err
+		}
+		var trend Trend
+		if len(raw.Trend) > 0 {
+			if err := trend.UnmarshalJSON(raw.Trend); err != nil {
+				return err
+			}
+		}
+		r.Time = timestamp
+		r.Value = raw.Value
+		r.Trend = trend
+		return nil
+	}
+
+	var n normalizedReading
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	r.Time = n.Time
+	r.Value = n.Value
+	r.Trend = n.Trend
+	return nil
+}
@@ -0,0 +1,117 @@
+package dexgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTrendUnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Trend
+	}{
+		{`3`, TrendFortyFiveUp},
+		{`"FortyFiveUp"`, TrendFortyFiveUp},
+		{`9`, TrendRateOutOfRange},
+		{`"RateOutOfRange"`, TrendRateOutOfRange},
+	}
+	for _, c := range cases {
+		var got Trend
+		if err := json.Unmarshal([]byte(c.in), &got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", c.in, err)
+		}
+		if got != c.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestTrendUnmarshalJSONUnknownName(t *testing.T) {
+	var got Trend
+	if err := json.Unmarshal([]byte(`"Sideways"`), &got); err == nil {
+		t.Fatal("expected error for unknown trend name, got nil")
+	}
+}
+
+func TestTrendArrow(t *testing.T) {
+	if arrow := TrendFlat.Arrow(); arrow != "→" {
+		t.Errorf("TrendFlat.Arrow() = %q, want %q", arrow, "→")
+	}
+	if arrow := Trend(99).Arrow(); arrow != "?" {
+		t.Errorf("Trend(99).Arrow() = %q, want %q", arrow, "?")
+	}
+}
+
+func TestGlucoseReadingUnmarshalWireFormat(t *testing.T) {
+	wt := "/Date(1700000000000)/"
+	data := []byte(fmt.Sprintf(`{"WT":%q,"ST":%q,"DT":%q,"Value":123,"Trend":3}`, wt, wt, wt))
+
+	var r GlucoseReading
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if want := time.UnixMilli(1700000000000); !r.Time.Equal(want) {
+		t.Errorf("Time = %v, want %v", r.Time, want)
+	}
+	if r.Value != 123 {
+		t.Errorf("Value = %d, want 123", r.Value)
+	}
+	if r.Trend != TrendFortyFiveUp {
+		t.Errorf("Trend = %v, want %v", r.Trend, TrendFortyFiveUp)
+	}
+}
+
+func TestGlucoseReadingUnmarshalWireFormatStringTrend(t *testing.T) {
+	wt := "/Date(1700000000000)/"
+	data := []byte(fmt.Sprintf(`{"WT":%q,"Value":80,"Trend":"Flat"}`, wt))
+
+	var r GlucoseReading
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if r.Trend != TrendFlat {
+		t.Errorf("Trend = %v, want %v", r.Trend, TrendFlat)
+	}
+}
+
+func TestGlucoseReadingRoundTripNormalizedJSON(t *testing.T) {
+	in := GlucoseReading{
+		Time:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Value: 142,
+		Trend: TrendSingleDown,
+	}
+
+	marshalled, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var out GlucoseReading
+	if err := json.Unmarshal(marshalled, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !out.Time.Equal(in.Time) || out.Value != in.Value || out.Trend != in.Trend {
+		t.Errorf("round trip mismatch: got %+v, want %+v", out, in)
+	}
+}
+
+func TestGlucoseReadingMarshalUsesNormalizedForm(t *testing.T) {
+	r := GlucoseReading{Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Value: 100, Trend: TrendFlat}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	for _, key := range []string{"time", "value", "trend"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("marshalled JSON missing %q field: %s", key, data)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package dexgo
+
+// Region selects which Dexcom Share host an account was registered
+// against. Accounts are region-locked: authenticating against the wrong
+// region's host fails even with correct credentials.
+type Region string
+
+const (
+	RegionUS  Region = "us"
+	RegionOUS Region = "ous"
+	RegionJP  Region = "jp"
+)
+
+var regionBaseURLs = map[Region]string{
+	RegionUS:  defaultBaseURL,
+	RegionOUS: "https://shareous1.dexcom.com/ShareWebServices/Services",
+	RegionJP:  "https://share.dexcom.jp/ShareWebServices/Services",
+}
+
+// regionApplicationIds holds the Share applicationIds that differ by
+// region. The JP host rejects the US/OUS id outright; regions absent here
+// fall back to defaultApplicationId.
+var regionApplicationIds = map[Region]string{
+	RegionJP: "d8665ade-9673-4e27-9ff6-92db4ce13d13",
+}
+
+// NewWithRegion creates a Dexcom client targeting the Share host and
+// applicationId for the given region. A WithBaseURL or WithApplicationId
+// option, if passed, takes precedence over the region (useful for
+// self-hosted proxies).
+func NewWithRegion(username string, password string, region Region, opts ...Option) Dexcom {
+	d := New(username, password, opts...)
+	if d.baseURL == "" {
+		d.baseURL = regionBaseURLs[region]
+	}
+	if d.applicationId == "" {
+		d.applicationId = regionApplicationIds[region]
+	}
+	return d
+}
@@ -0,0 +1,27 @@
+package dexgo
+
+import "testing"
+
+func TestNewWithRegionSetsApplicationId(t *testing.T) {
+	cases := []struct {
+		region Region
+		want   string
+	}{
+		{RegionUS, defaultApplicationId},
+		{RegionOUS, defaultApplicationId},
+		{RegionJP, "d8665ade-9673-4e27-9ff6-92db4ce13d13"},
+	}
+	for _, c := range cases {
+		d := NewWithRegion("alice", "hunter2", c.region)
+		if got := d.applicationIdOrDefault(); got != c.want {
+			t.Errorf("NewWithRegion(%s).applicationIdOrDefault() = %q, want %q", c.region, got, c.want)
+		}
+	}
+}
+
+func TestNewWithRegionWithApplicationIdOverridesRegion(t *testing.T) {
+	d := NewWithRegion("alice", "hunter2", RegionJP, WithApplicationId("custom-app-id"))
+	if got := d.applicationIdOrDefault(); got != "custom-app-id" {
+		t.Errorf("applicationIdOrDefault() = %q, want %q", got, "custom-app-id")
+	}
+}
@@ -0,0 +1,172 @@
+package dexgo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// SessionStore persists the accountId/sessionId pair for a Dexcom account
+// across process restarts. Implementations must treat a missing entry as a
+// cache miss, not an error: Load should return empty strings and a nil
+// error when there's nothing cached for username.
+type SessionStore interface {
+	Load(username string) (accountId string, sessionId string, err error)
+	Save(username string, accountId string, sessionId string) error
+	Clear(username string) error
+}
+
+// FileSessionStore persists sessions to a single JSON file, with each
+// entry encrypted at rest using a key derived from the account's Dexcom
+// password via scrypt, so a stolen cache file doesn't hand out a live
+// session.
+type FileSessionStore struct {
+	path     string
+	password string
+}
+
+// sessionFileEntry is one account's encrypted session, as stored on disk.
+type sessionFileEntry struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+type sessionPayload struct {
+	AccountId string `json:"accountId"`
+	SessionId string `json:"sessionId"`
+}
+
+const scryptN = 1 << 15
+const scryptR = 8
+const scryptP = 1
+const scryptKeyLen = 32
+
+// NewFileSessionStore creates a FileSessionStore that encrypts entries with
+// a key derived from password. If path is empty, it defaults to
+// "dexgo/sessions.json" under os.UserCacheDir().
+func NewFileSessionStore(password string, path string) (*FileSessionStore, error) {
+	if path == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, "dexgo", "sessions.json")
+	}
+	return &FileSessionStore{path: path, password: password}, nil
+}
+
+func (s *FileSessionStore) deriveKey(salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(s.password), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+}
+
+func (s *FileSessionStore) readAll() (map[string]sessionFileEntry, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]sessionFileEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]sessionFileEntry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *FileSessionStore) writeAll(entries map[string]sessionFileEntry) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func (s *FileSessionStore) Load(username string) (string, string, error) {
+	entries, err := s.readAll()
+	if err != nil {
+		return "", "", err
+	}
+	entry, ok := entries[username]
+	if !ok {
+		return "", "", nil
+	}
+
+	key, err := s.deriveKey(entry.Salt)
+	if err != nil {
+		return "", "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", "", err
+	}
+	plaintext, err := gcm.Open(nil, entry.Nonce, entry.Ciphertext, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("dexgo: decrypting cached session for %s: %w", username, err)
+	}
+
+	var payload sessionPayload
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return "", "", err
+	}
+	return payload.AccountId, payload.SessionId, nil
+}
+
+func (s *FileSessionStore) Save(username string, accountId string, sessionId string) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	key, err := s.deriveKey(salt)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(sessionPayload{AccountId: accountId, SessionId: sessionId})
+	if err != nil {
+		return err
+	}
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	entries, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	entries[username] = sessionFileEntry{Salt: salt, Nonce: nonce, Ciphertext: ciphertext}
+	return s.writeAll(entries)
+}
+
+func (s *FileSessionStore) Clear(username string) error {
+	entries, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	delete(entries, username)
+	return s.writeAll(entries)
+}
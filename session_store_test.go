@@ -0,0 +1,83 @@
+package dexgo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSessionStoreSaveLoadRoundTrip(t *testing.T) {
+	store := &FileSessionStore{path: filepath.Join(t.TempDir(), "sessions.json"), password: "hunter2"}
+
+	if err := store.Save("alice", "acct-1", "sess-1"); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	accountId, sessionId, err := store.Load("alice")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if accountId != "acct-1" || sessionId != "sess-1" {
+		t.Errorf("Load() = (%q, %q), want (%q, %q)", accountId, sessionId, "acct-1", "sess-1")
+	}
+}
+
+func TestFileSessionStoreLoadMissingEntryIsNotError(t *testing.T) {
+	store := &FileSessionStore{path: filepath.Join(t.TempDir(), "sessions.json"), password: "hunter2"}
+
+	accountId, sessionId, err := store.Load("nobody")
+	if err != nil {
+		t.Fatalf("Load of missing entry returned error: %v", err)
+	}
+	if accountId != "" || sessionId != "" {
+		t.Errorf("Load() of missing entry = (%q, %q), want empty strings", accountId, sessionId)
+	}
+}
+
+func TestFileSessionStoreWrongPasswordFailsToDecrypt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	store := &FileSessionStore{path: path, password: "correct-password"}
+	if err := store.Save("alice", "acct-1", "sess-1"); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	other := &FileSessionStore{path: path, password: "wrong-password"}
+	if _, _, err := other.Load("alice"); err == nil {
+		t.Fatal("Load with wrong password succeeded, want error")
+	}
+}
+
+func TestFileSessionStoreClear(t *testing.T) {
+	store := &FileSessionStore{path: filepath.Join(t.TempDir(), "sessions.json"), password: "hunter2"}
+	if err := store.Save("alice", "acct-1", "sess-1"); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if err := store.Clear("alice"); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+
+	accountId, sessionId, err := store.Load("alice")
+	if err != nil {
+		t.Fatalf("Load after Clear: %v", err)
+	}
+	if accountId != "" || sessionId != "" {
+		t.Errorf("Load() after Clear = (%q, %q), want empty strings", accountId, sessionId)
+	}
+}
+
+func TestFileSessionStoreSaveIsPerAccount(t *testing.T) {
+	store := &FileSessionStore{path: filepath.Join(t.TempDir(), "sessions.json"), password: "hunter2"}
+	if err := store.Save("alice", "acct-1", "sess-1"); err != nil {
+		t.Fatalf("Save(alice): %v", err)
+	}
+	if err := store.Save("bob", "acct-2", "sess-2"); err != nil {
+		t.Fatalf("Save(bob): %v", err)
+	}
+
+	if accountId, sessionId, err := store.Load("alice"); err != nil || accountId != "acct-1" || sessionId != "sess-1" {
+		t.Errorf("Load(alice) = (%q, %q, %v), want (acct-1, sess-1, nil)", accountId, sessionId, err)
+	}
+	if accountId, sessionId, err := store.Load("bob"); err != nil || accountId != "acct-2" || sessionId != "sess-2" {
+		t.Errorf("Load(bob) = (%q, %q, %v), want (acct-2, sess-2, nil)", accountId, sessionId, err)
+	}
+}
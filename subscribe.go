@@ -0,0 +1,160 @@
+package dexgo
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// SubscribeOptions configures the polling behavior of Subscribe.
+type SubscribeOptions struct {
+	// MinPollInterval bounds how often we're willing to hit the Share API,
+	// even if a reading looks overdue. Defaults to 30s.
+	MinPollInterval time.Duration
+	// MaxPollInterval bounds how long we'll wait between polls, including
+	// while backing off after errors. Defaults to 10 minutes.
+	MaxPollInterval time.Duration
+	// Backfill emits the most recent readings already available on the
+	// Share account as soon as Subscribe is called, before waiting for new
+	// ones.
+	Backfill bool
+	// BackfillCount is how many past readings to emit when Backfill is set.
+	// Defaults to 1.
+	BackfillCount int
+}
+
+const (
+	defaultMinPollInterval = 30 * time.Second
+	defaultMaxPollInterval = 10 * time.Minute
+	readingCadence         = 5 * time.Minute
+	pollJitterMax          = 20 * time.Second
+	pollWindowMinutes      = 30
+)
+
+func (o SubscribeOptions) withDefaults() SubscribeOptions {
+	if o.MinPollInterval <= 0 {
+		o.MinPollInterval = defaultMinPollInterval
+	}
+	if o.MaxPollInterval <= 0 {
+		o.MaxPollInterval = defaultMaxPollInterval
+	}
+	if o.BackfillCount <= 0 {
+		o.BackfillCount = 1
+	}
+	return o
+}
+
+// Subscribe polls the Share backend on a schedule aligned to the CGM's
+// 5-minute reading cadence and emits each new GlucoseReading exactly once
+// on the returned channel, in chronological order. Both channels are closed
+// once ctx is done; the error channel also receives (without closing) poll
+// failures encountered along the way. Errors are sent without blocking: a
+// failure is dropped if the previous one has not been read yet.
+func (d *Dexcom) Subscribe(ctx context.Context, opts SubscribeOptions) (<-chan GlucoseReading, <-chan error) {
+	opts = opts.withDefaults()
+	readings := make(chan GlucoseReading)
+	errs := make(chan error, 1)
+
+	go d.subscribeLoop(ctx, opts, readings, errs)
+
+	return readings, errs
+}
+
+func (d *Dexcom) subscribeLoop(ctx context.Context, opts SubscribeOptions, readings chan<- GlucoseReading, errs chan<- error) {
+	defer close(readings)
+	defer close(errs)
+
+	var lastSeen time.Time
+	backoff := opts.MinPollInterval
+	var retryAfter time.Duration
+
+	poll := func() ([]GlucoseReading, error) {
+		return d.GetReadingsContext(ctx, pollWindowMinutes, 12)
+	}
+
+	initial, err := poll()
+	if err != nil {
+		d.emitErr(ctx, errs, err)
+	} else if len(initial) > 0 {
+		if opts.Backfill {
+			n := opts.BackfillCount
+			if n > len(initial) {
+				n = len(initial)
+			}
+			for i := n - 1; i >= 0; i-- {
+				if !d.emitReading(ctx, readings, initial[i]) {
+					return
+				}
+			}
+		}
+		lastSeen = initial[0].Time
+		backoff = opts.MinPollInterval
+	}
+
+	for {
+		wait := opts.MinPollInterval
+		if retryAfter > 0 {
+			wait = retryAfter
+			retryAfter = 0
+		} else if !lastSeen.IsZero() {
+			jitter := time.Duration(rand.Int63n(int64(pollJitterMax)))
+			wait = time.Until(lastSeen.Add(readingCadence + jitter))
+			if wait < opts.MinPollInterval {
+				wait = opts.MinPollInterval
+			}
+			if wait > opts.MaxPollInterval {
+				wait = opts.MaxPollInterval
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		latest, err := poll()
+		if err != nil {
+			d.emitErr(ctx, errs, err)
+			backoff *= 2
+			if backoff > opts.MaxPollInterval {
+				backoff = opts.MaxPollInterval
+			}
+			retryAfter = backoff
+			continue
+		}
+		backoff = opts.MinPollInterval
+
+		fresh := make([]GlucoseReading, 0, len(latest))
+		for _, r := range latest {
+			if r.Time.After(lastSeen) {
+				fresh = append(fresh, r)
+			}
+		}
+		for i := len(fresh) - 1; i >= 0; i-- {
+			if !d.emitReading(ctx, readings, fresh[i]) {
+				return
+			}
+		}
+		if len(latest) > 0 {
+			lastSeen = latest[0].Time
+		}
+	}
+}
+
+func (d *Dexcom) emitReading(ctx context.Context, readings chan<- GlucoseReading, r GlucoseReading) bool {
+	select {
+	case readings <- r:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (d *Dexcom) emitErr(ctx context.Context, errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	case <-ctx.Done():
+	default:
+	}
+}
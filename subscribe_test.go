@@ -0,0 +1,166 @@
+package dexgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// wireJSON renders a GlucoseReading as the raw Dexcom wire form a real
+// ReadPublisherLatestGlucoseValues response would use.
+func wireJSON(t time.Time, value int, trend Trend) string {
+	return fmt.Sprintf(`{"WT":"/Date(%d)/","Value":%d,"Trend":%d}`, t.UnixMilli(), value, int8(trend))
+}
+
+// subscribeTestServer serves ReadPublisherLatestGlucoseValues, returning
+// readingsByCall[min(call, len-1)] for each successive call.
+func subscribeTestServer(t *testing.T, readingsByCall [][]string) *httptest.Server {
+	t.Helper()
+	var calls int64
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&calls, 1) - 1
+		readings := readingsByCall[len(readingsByCall)-1]
+		if int(n) < len(readingsByCall) {
+			readings = readingsByCall[n]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, "[%s]", joinJSON(readings))
+	}))
+}
+
+func joinJSON(items []string) string {
+	out := ""
+	for i, item := range items {
+		if i > 0 {
+			out += ","
+		}
+		out += item
+	}
+	return out
+}
+
+func newTestSubscriber(t *testing.T, server *httptest.Server) *Dexcom {
+	t.Helper()
+	d := New("alice", "hunter2", WithHTTPClient(server.Client()), WithBaseURL(server.URL))
+	sessionId := "test-session"
+	d.sessionId = &sessionId
+	return &d
+}
+
+func TestSubscribeDedupesRepeatedPolls(t *testing.T) {
+	now := time.Now().Add(-2 * time.Hour)
+	readingA := wireJSON(now, 100, TrendFlat)
+	readingB := wireJSON(now.Add(5*time.Minute), 110, TrendSingleUp)
+
+	server := subscribeTestServer(t, [][]string{
+		{readingA}, // initial poll
+		{readingB}, // first loop poll picks up the new reading
+		{readingB}, // subsequent polls repeat it; must not re-emit
+	})
+	defer server.Close()
+
+	d := newTestSubscriber(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Millisecond)
+	defer cancel()
+
+	readings, errs := d.Subscribe(ctx, SubscribeOptions{
+		MinPollInterval: 5 * time.Millisecond,
+		MaxPollInterval: 10 * time.Millisecond,
+	})
+
+	var got []GlucoseReading
+	for r := range readings {
+		got = append(got, r)
+	}
+	for err := range errs {
+		// A poll racing the context deadline may report it on errs; see
+		// TestSubscribeClosesBothChannelsWhenContextDone.
+		if errors.Is(err, context.DeadlineExceeded) {
+			continue
+		}
+		t.Errorf("unexpected error on errs channel: %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("got %d readings, want 1 (dedup failed): %+v", len(got), got)
+	}
+	if got[0].Value != 110 {
+		t.Errorf("emitted reading value = %d, want 110", got[0].Value)
+	}
+}
+
+func TestSubscribeClosesBothChannelsWhenContextDone(t *testing.T) {
+	now := time.Now().Add(-2 * time.Hour)
+	server := subscribeTestServer(t, [][]string{{wireJSON(now, 100, TrendFlat)}})
+	defer server.Close()
+
+	d := newTestSubscriber(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	readings, errs := d.Subscribe(ctx, SubscribeOptions{
+		MinPollInterval: 5 * time.Millisecond,
+		MaxPollInterval: 10 * time.Millisecond,
+	})
+
+	for range readings {
+	}
+
+	// A poll may legitimately race the context deadline and land a
+	// "context deadline exceeded" error on errs before it closes; drain any
+	// of those and just confirm the channel is closed afterward.
+	done := make(chan struct{})
+	go func() {
+		for range errs {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("errs channel was never closed after ctx was done")
+	}
+}
+
+func TestSubscribeBackfillEmitsInitialReadings(t *testing.T) {
+	now := time.Now().Add(-2 * time.Hour)
+	initial := []string{
+		wireJSON(now.Add(10*time.Minute), 130, TrendFlat),
+		wireJSON(now.Add(5*time.Minute), 120, TrendFlat),
+		wireJSON(now, 110, TrendFlat),
+	}
+	server := subscribeTestServer(t, [][]string{initial})
+	defer server.Close()
+
+	d := newTestSubscriber(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	readings, _ := d.Subscribe(ctx, SubscribeOptions{
+		MinPollInterval: 5 * time.Millisecond,
+		MaxPollInterval: 10 * time.Millisecond,
+		Backfill:        true,
+		BackfillCount:   2,
+	})
+
+	var got []GlucoseReading
+	for r := range readings {
+		got = append(got, r)
+	}
+
+	// BackfillCount is 2: the 2 most recent readings, oldest-of-those-first.
+	if len(got) < 2 {
+		t.Fatalf("got %d backfilled readings, want at least 2: %+v", len(got), got)
+	}
+	if got[0].Value != 120 || got[1].Value != 130 {
+		t.Errorf("backfilled readings out of order: got values %d, %d, want 120, 130", got[0].Value, got[1].Value)
+	}
+}